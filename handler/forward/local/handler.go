@@ -9,6 +9,7 @@ import (
 	"net"
 	"net/http"
 	"net/http/httputil"
+	"strings"
 	"time"
 
 	"github.com/go-gost/core/chain"
@@ -31,6 +32,7 @@ type forwardHandler struct {
 	router  *chain.Router
 	md      metadata
 	options handler.Options
+	pool    *connPool
 }
 
 func NewHandler(opts ...handler.Option) handler.Handler {
@@ -53,6 +55,7 @@ func (h *forwardHandler) Init(md md.Metadata) (err error) {
 	if h.router == nil {
 		h.router = chain.NewRouter(chain.LoggerRouterOption(h.options.Logger))
 	}
+	h.pool = newConnPool(h.md.httpPoolMaxIdle)
 
 	return
 }
@@ -90,11 +93,11 @@ func (h *forwardHandler) Handle(ctx context.Context, conn net.Conn, opts ...hand
 	var rw io.ReadWriter = conn
 	var host string
 	var protocol string
+	var sniffMetadata map[string]string
 	if h.md.sniffing {
-		if network == "tcp" {
-			rw, host, protocol, _ = forward.Sniffing(ctx, conn)
-			h.options.Logger.Debugf("sniffing: host=%s, protocol=%s", host, protocol)
-		}
+		var result *forward.Result
+		rw, result = h.sniff(ctx, conn, network, log)
+		host, protocol, sniffMetadata = result.Host, result.Protocol, result.Metadata
 	}
 
 	if _, _, err := net.SplitHostPort(host); err != nil {
@@ -116,11 +119,39 @@ func (h *forwardHandler) Handle(ctx context.Context, conn net.Conn, opts ...hand
 	}
 
 	log = log.WithFields(map[string]any{
-		"dst": fmt.Sprintf("%s/%s", target.Addr, network),
+		"dst":      fmt.Sprintf("%s/%s", target.Addr, network),
+		"dstName":  h.lookupAddr(host),
+		"sniffing": sniffMetadata,
 	})
 
 	log.Debugf("%s >> %s", conn.RemoteAddr(), target.Addr)
 
+	t := time.Now()
+
+	// HTTP gets a real L7 reverse proxy treatment: every request is
+	// re-selected against h.hop on its own, so a hop with multiple
+	// backends load-balances per request rather than pinning the whole
+	// TCP connection to whichever node the initial sniff landed on.
+	//
+	// This is deliberately a property of the hop (protocol sniffed as
+	// HTTP, and a hop configured to select from), not of whichever node
+	// the one-shot selection above happened to land on: gating on a
+	// single node's Options().HTTP would make the same hop flip between
+	// L7 proxying and raw relay depending on which backend the initial,
+	// non-per-request Select returned.
+	if protocol == forward.ProtoHTTP && h.hop != nil {
+		err := h.handleHTTP(ctx, rw, conn, log)
+		log.WithFields(map[string]any{
+			"duration": time.Since(t),
+		}).Debugf("%s >-< %s", conn.RemoteAddr(), target.Addr)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		return nil
+	}
+
+	log.Debugf("%s <-> %s", conn.RemoteAddr(), target.Addr)
+
 	cc, err := h.router.Dial(ctx, network, target.Addr)
 	if err != nil {
 		log.Error(err)
@@ -136,15 +167,7 @@ func (h *forwardHandler) Handle(ctx context.Context, conn net.Conn, opts ...hand
 		marker.Reset()
 	}
 
-	t := time.Now()
-	log.Debugf("%s <-> %s", conn.RemoteAddr(), target.Addr)
-
-	if protocol == forward.ProtoHTTP &&
-		target.Options().HTTP != nil {
-		h.handleHTTP(ctx, rw, cc, target.Options().HTTP, log)
-	} else {
-		xnet.Transport(rw, cc)
-	}
+	xnet.Transport(ctx, rw, cc)
 
 	log.WithFields(map[string]any{
 		"duration": time.Since(t),
@@ -153,6 +176,97 @@ func (h *forwardHandler) Handle(ctx context.Context, conn net.Conn, opts ...hand
 	return nil
 }
 
+// lookupAddr resolves dst's IP back to the canonical name it's
+// registered under in h.md.hosts, for log annotation. dst may be a
+// bare IP or an "ip:port" pair; anything else (an already-sniffed
+// hostname, an empty value) reports no name.
+func (h *forwardHandler) lookupAddr(dst string) string {
+	if h.md.hosts == "" {
+		return ""
+	}
+	mapper := registry.HostsRegistry().Get(h.md.hosts)
+	if mapper == nil {
+		return ""
+	}
+	al, ok := mapper.(registry.AddrLookuper)
+	if !ok {
+		return ""
+	}
+
+	host := dst
+	if h, _, err := net.SplitHostPort(dst); err == nil {
+		host = h
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return ""
+	}
+
+	names, ok := al.LookupAddr(ip)
+	if !ok || len(names) == 0 {
+		return ""
+	}
+	return names[0]
+}
+
+// sniff runs the protocol sniffer subsystem over conn and returns a
+// stream that replays any bytes it peeked at, so the caller can keep
+// using it exactly as it would conn. It never returns a nil result: on
+// timeout or when no detector matches, the returned result is empty and
+// the caller falls back to unsniffed forwarding.
+func (h *forwardHandler) sniff(ctx context.Context, conn net.Conn, network string, log logger.Logger) (io.ReadWriter, *forward.Result) {
+	sniffers := forward.Sniffers()
+	for _, name := range h.md.sniffingDetectors {
+		if s := registry.SnifferRegistry().Get(name); s != nil {
+			sniffers = append(sniffers, s)
+		}
+	}
+	opts := &forward.Options{
+		MaxBufferSize: h.md.sniffingMaxBufferSize,
+		Timeout:       h.md.sniffingTimeout,
+		Sniffers:      sniffers,
+	}
+
+	if network == "udp" {
+		buf := make([]byte, h.md.sniffingMaxBufferSize)
+		conn.SetReadDeadline(time.Now().Add(h.md.sniffingTimeout))
+		n, err := conn.Read(buf)
+		conn.SetReadDeadline(time.Time{})
+		if err != nil {
+			log.Debugf("sniffing: %v", err)
+			return conn, &forward.Result{}
+		}
+		result, err := forward.SniffPacket(ctx, buf[:n])
+		if err != nil {
+			return &rewindUDPConn{Conn: conn, buf: buf[:n]}, &forward.Result{}
+		}
+		return &rewindUDPConn{Conn: conn, buf: buf[:n]}, result
+	}
+
+	rw, result, err := forward.Sniffing(ctx, conn, opts)
+	if err != nil {
+		log.Debugf("sniffing: %v", err)
+		return conn, &forward.Result{}
+	}
+	return rw, result
+}
+
+// rewindUDPConn replays the one datagram consumed while sniffing before
+// falling back to reading further packets off conn.
+type rewindUDPConn struct {
+	net.Conn
+	buf  []byte
+	read bool
+}
+
+func (c *rewindUDPConn) Read(p []byte) (int, error) {
+	if !c.read {
+		c.read = true
+		return copy(p, c.buf), nil
+	}
+	return c.Conn.Read(p)
+}
+
 func (h *forwardHandler) checkRateLimit(addr net.Addr) bool {
 	if h.options.RateLimiter == nil {
 		return true
@@ -165,55 +279,134 @@ func (h *forwardHandler) checkRateLimit(addr net.Addr) bool {
 	return true
 }
 
-func (h *forwardHandler) handleHTTP(ctx context.Context, src, dst io.ReadWriter, httpSettings *chain.HTTPNodeSettings, log logger.Logger) error {
-	errc := make(chan error, 1)
-	go func() {
-		errc <- xnet.CopyBuffer(src, dst, 8192)
-	}()
+// handleHTTP reads requests off src one at a time and, for each one,
+// re-runs hop selection so a hop with several backends load-balances
+// per request instead of pinning the whole connection to one node.
+// conn's deadlines bound how long we wait between requests (idle) and
+// how long any one request may take end to end, so a slow or silent
+// client can't hold the goroutine open indefinitely.
+func (h *forwardHandler) handleHTTP(ctx context.Context, src io.ReadWriter, conn net.Conn, log logger.Logger) error {
+	br := bufio.NewReader(src)
+	for {
+		if h.md.idleTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(h.md.idleTimeout))
+		}
+		req, err := http.ReadRequest(br)
+		if err != nil {
+			return err
+		}
 
-	go func() {
-		br := bufio.NewReader(src)
-		for {
-			err := func() error {
-				req, err := http.ReadRequest(br)
-				if err != nil {
-					return err
-				}
-
-				if httpSettings.Host != "" {
-					req.Host = httpSettings.Host
-				}
-				for k, v := range httpSettings.Header {
-					req.Header.Set(k, v)
-				}
-
-				if log.IsLevelEnabled(logger.TraceLevel) {
-					dump, _ := httputil.DumpRequest(req, false)
-					log.Trace(string(dump))
-				}
-				if err := req.Write(dst); err != nil {
-					return err
-				}
-
-				if req.Header.Get("Upgrade") == "websocket" {
-					err := xnet.CopyBuffer(dst, src, 8192)
-					if err == nil {
-						err = io.EOF
-					}
-					return err
-				}
-				return nil
-			}()
-			if err != nil {
-				errc <- err
-				break
-			}
+		if h.md.requestTimeout > 0 {
+			conn.SetDeadline(time.Now().Add(h.md.requestTimeout))
+		} else {
+			conn.SetReadDeadline(time.Time{})
 		}
-	}()
 
-	if err := <-errc; err != nil && err != io.EOF {
+		if err := h.forwardHTTPRequest(ctx, src, req, log); err != nil {
+			return err
+		}
+	}
+}
+
+// forwardHTTPRequest selects a backend for req, writes it to a
+// connection checked out of h.pool (or a freshly dialed one), and
+// copies the response back using http.ReadResponse so Content-Length
+// and chunked framing are respected rather than blindly streamed.
+// Upgraded connections (websocket, h2c) fall back to a raw byte-level
+// transport for the remainder of the connection.
+func (h *forwardHandler) forwardHTTPRequest(ctx context.Context, src io.ReadWriter, req *http.Request, log logger.Logger) error {
+	target := h.hop.Select(ctx,
+		chain.HostSelectOption(req.Host),
+		chain.PathSelectOption(req.URL.Path),
+		chain.MethodSelectOption(req.Method),
+		chain.HeaderSelectOption(req.Header),
+	)
+	if target == nil {
+		err := errors.New("target not available")
+		log.Error(err)
+		return err
+	}
+
+	cc, err := h.dialBackend(ctx, target.Addr)
+	if err != nil {
+		log.Error(err)
+		if marker := target.Marker(); marker != nil {
+			marker.Mark()
+		}
 		return err
 	}
+	if marker := target.Marker(); marker != nil {
+		marker.Reset()
+	}
+
+	if h.md.requestTimeout > 0 {
+		cc.SetDeadline(time.Now().Add(h.md.requestTimeout))
+	} else {
+		cc.SetDeadline(time.Time{})
+	}
+
+	if httpSettings := target.Options().HTTP; httpSettings != nil {
+		if httpSettings.Host != "" {
+			req.Host = httpSettings.Host
+		}
+		for k, v := range httpSettings.Header {
+			req.Header.Set(k, v)
+		}
+	}
+
+	if log.IsLevelEnabled(logger.TraceLevel) {
+		dump, _ := httputil.DumpRequest(req, false)
+		log.Trace(string(dump))
+	}
+
+	if err := req.Write(cc); err != nil {
+		cc.Close()
+		return err
+	}
+
+	upgrade := req.Header.Get("Upgrade")
+	if strings.EqualFold(upgrade, "websocket") || strings.EqualFold(upgrade, "h2c") {
+		defer cc.Close()
+		// an upgraded connection is long-lived by design; drop the
+		// per-request deadlines set above and by handleHTTP.
+		cc.SetDeadline(time.Time{})
+		if conn, ok := src.(net.Conn); ok {
+			conn.SetDeadline(time.Time{})
+		}
+		err := xnet.Transport(ctx, src, cc)
+		if err == nil {
+			err = io.EOF
+		}
+		return err
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(cc), req)
+	if err != nil {
+		cc.Close()
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := resp.Write(src); err != nil {
+		cc.Close()
+		return err
+	}
+
+	if resp.Close || req.Close {
+		cc.Close()
+	} else {
+		h.pool.put(target.Addr, cc)
+	}
 
 	return nil
 }
+
+// dialBackend checks out a keepalive connection to addr from h.pool,
+// dialing a new one if the pool is empty so hot backends don't pay
+// reconnect cost on every request.
+func (h *forwardHandler) dialBackend(ctx context.Context, addr string) (net.Conn, error) {
+	if cc := h.pool.get(addr); cc != nil {
+		return cc, nil
+	}
+	return h.router.Dial(ctx, "tcp", addr)
+}
@@ -0,0 +1,165 @@
+package local
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-gost/core/chain"
+	"github.com/go-gost/core/handler"
+	"github.com/go-gost/core/logger"
+)
+
+// fakeHop always selects the same node, regardless of the select
+// options passed in; that's enough to drive forwardHTTPRequest without
+// depending on the real chain.Hop implementation.
+type fakeHop struct {
+	node *chain.Node
+}
+
+func (h *fakeHop) Select(ctx context.Context, opts ...chain.SelectOption) *chain.Node {
+	return h.node
+}
+
+func newTestHandler(pool *connPool, node *chain.Node) *forwardHandler {
+	return &forwardHandler{
+		hop:     &fakeHop{node: node},
+		pool:    pool,
+		options: handler.Options{Logger: logger.Default()},
+		md:      metadata{requestTimeout: 2 * time.Second},
+	}
+}
+
+func TestForwardHTTPRequestReusesPooledConnection(t *testing.T) {
+	backend, pooled := net.Pipe()
+	defer backend.Close()
+
+	pool := newConnPool(4)
+	pool.put("backend:1", pooled)
+
+	h := newTestHandler(pool, &chain.Node{Addr: "backend:1"})
+	log := h.options.Logger
+
+	clientSide, srcConn := net.Pipe()
+	defer clientSide.Close()
+
+	backendDone := make(chan *http.Request, 1)
+	go func() {
+		req, err := http.ReadRequest(bufio.NewReader(backend))
+		if err != nil {
+			backendDone <- nil
+			return
+		}
+		backendDone <- req
+		resp := "HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok"
+		backend.Write([]byte(resp))
+	}()
+
+	req, err := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	req.Host = "example.com"
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- h.forwardHTTPRequest(context.Background(), srcConn, req, log)
+	}()
+
+	select {
+	case got := <-backendDone:
+		if got == nil || got.Host != "example.com" {
+			t.Fatalf("backend did not see the forwarded request: %+v", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("backend never received the request")
+	}
+
+	br := bufio.NewReader(clientSide)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		t.Fatalf("ReadResponse: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	// net.Pipe is unbuffered: forwardHTTPRequest only returns once the
+	// body it relays has been read off clientSide.
+	if body, err := io.ReadAll(resp.Body); err != nil || string(body) != "ok" {
+		t.Fatalf("body = %q, %v, want %q", body, err, "ok")
+	}
+
+	if err := <-errc; err != nil {
+		t.Fatalf("forwardHTTPRequest: %v", err)
+	}
+
+	// a non-Close response with no Upgrade should go back to the pool
+	// for reuse rather than being closed.
+	if got := pool.get("backend:1"); got != pooled {
+		t.Fatalf("expected the connection to be returned to the pool, got %v", got)
+	}
+}
+
+func TestForwardHTTPRequestUpgradeFallsBackToRawTransport(t *testing.T) {
+	backend, pooled := net.Pipe()
+	defer backend.Close()
+
+	pool := newConnPool(4)
+	pool.put("backend:1", pooled)
+
+	h := newTestHandler(pool, &chain.Node{Addr: "backend:1"})
+	log := h.options.Logger
+
+	clientSide, srcConn := net.Pipe()
+	defer clientSide.Close()
+
+	req, err := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	req.Host = "example.com"
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- h.forwardHTTPRequest(context.Background(), srcConn, req, log)
+	}()
+
+	// Once upgraded, bytes flow raw in both directions instead of being
+	// framed as HTTP requests/responses. Draining backend's read side
+	// is what lets req.Write(cc) inside forwardHTTPRequest unblock.
+	go io.Copy(io.Discard, backend)
+
+	if _, err := backend.Write([]byte("switched-protocols-bytes")); err != nil {
+		t.Fatalf("backend write: %v", err)
+	}
+
+	buf := make([]byte, len("switched-protocols-bytes"))
+	clientSide.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err := io.ReadFull(clientSide, buf); err != nil {
+		t.Fatalf("client read: %v", err)
+	}
+	if string(buf) != "switched-protocols-bytes" {
+		t.Fatalf("got %q, want the raw upgrade bytes relayed verbatim", buf)
+	}
+
+	backend.Close()
+	clientSide.Close()
+
+	select {
+	case <-errc:
+	case <-time.After(2 * time.Second):
+		t.Fatal("forwardHTTPRequest did not return once the upgraded transport closed")
+	}
+
+	// an upgraded connection is never returned to the pool.
+	if got := pool.get("backend:1"); got != nil {
+		t.Fatalf("expected the upgraded connection not to be pooled, got %v", got)
+	}
+}
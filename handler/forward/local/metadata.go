@@ -0,0 +1,70 @@
+package local
+
+import (
+	"time"
+
+	mdata "github.com/go-gost/core/metadata"
+	mdutil "github.com/go-gost/x/metadata/util"
+)
+
+const (
+	defaultSniffingTimeout    = 3 * time.Second
+	defaultSniffingBufferSize = 16 * 1024
+	defaultHTTPPoolMaxIdle    = 16
+	defaultIdleTimeout        = 60 * time.Second
+	defaultRequestTimeout     = 30 * time.Second
+)
+
+type metadata struct {
+	sniffing              bool
+	sniffingTimeout       time.Duration
+	sniffingMaxBufferSize int
+	// sniffingDetectors names additional forward.Sniffer detectors
+	// registered in registry.SnifferRegistry() to run alongside the
+	// built-in ones, e.g. for a proprietary protocol.
+	sniffingDetectors []string
+	// httpPoolMaxIdle bounds how many idle keepalive connections are
+	// kept per backend for the per-request HTTP reverse proxy path.
+	httpPoolMaxIdle int
+	// idleTimeout bounds how long the HTTP loop waits for the next
+	// request on a connection before giving up.
+	idleTimeout time.Duration
+	// requestTimeout bounds how long a single request, including
+	// writing it upstream and copying back the response, may take.
+	requestTimeout time.Duration
+	// hosts names a registry.HostsRegistry entry to consult for
+	// reverse-resolving a sniffed destination IP to a canonical name
+	// for logging; empty disables the lookup.
+	hosts string
+}
+
+func (h *forwardHandler) parseMetadata(md mdata.Metadata) (err error) {
+	h.md.sniffing = mdutil.GetBool(md, "sniffing")
+	h.md.sniffingTimeout = mdutil.GetDuration(md, "sniffing.timeout")
+	if h.md.sniffingTimeout <= 0 {
+		h.md.sniffingTimeout = defaultSniffingTimeout
+	}
+	h.md.sniffingMaxBufferSize = mdutil.GetInt(md, "sniffing.maxBufferSize")
+	if h.md.sniffingMaxBufferSize <= 0 {
+		h.md.sniffingMaxBufferSize = defaultSniffingBufferSize
+	}
+	h.md.sniffingDetectors = mdutil.GetStrings(md, "sniffing.detectors")
+
+	h.md.httpPoolMaxIdle = mdutil.GetInt(md, "http.pool.maxIdle")
+	if h.md.httpPoolMaxIdle <= 0 {
+		h.md.httpPoolMaxIdle = defaultHTTPPoolMaxIdle
+	}
+
+	h.md.idleTimeout = mdutil.GetDuration(md, "idleTimeout")
+	if h.md.idleTimeout <= 0 {
+		h.md.idleTimeout = defaultIdleTimeout
+	}
+	h.md.requestTimeout = mdutil.GetDuration(md, "timeout")
+	if h.md.requestTimeout <= 0 {
+		h.md.requestTimeout = defaultRequestTimeout
+	}
+
+	h.md.hosts = mdutil.GetString(md, "hosts")
+
+	return
+}
@@ -0,0 +1,83 @@
+package local
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// connPool is a small keepalive pool of upstream connections keyed by
+// the selected node's address, so repeated HTTP requests to the same
+// hot backend don't each pay the cost of a fresh dial.
+type connPool struct {
+	mu      sync.Mutex
+	idle    map[string][]net.Conn
+	maxIdle int
+}
+
+func newConnPool(maxIdle int) *connPool {
+	return &connPool{
+		idle:    make(map[string][]net.Conn),
+		maxIdle: maxIdle,
+	}
+}
+
+// get returns a live idle connection for addr, or nil if none is
+// available. A backend that closed the connection while it sat idle in
+// the pool (most commonly by resetting it) would otherwise be handed
+// back out and fail the caller's first real read, so each candidate is
+// probed with a zero-deadline read before being returned: data or EOF
+// means dead, and a timeout means it's merely idle, as expected.
+func (p *connPool) get(addr string) net.Conn {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	conns := p.idle[addr]
+	for len(conns) > 0 {
+		c := conns[len(conns)-1]
+		conns = conns[:len(conns)-1]
+		p.idle[addr] = conns
+
+		if !probeLive(c) {
+			c.Close()
+			continue
+		}
+		return c
+	}
+
+	return nil
+}
+
+// probeLive reports whether c still looks usable: a peer that closed
+// or reset the connection while it was idle will have something to read
+// (EOF, or a reset surfaced as an error) right away.
+func probeLive(c net.Conn) bool {
+	c.SetReadDeadline(time.Now())
+	defer c.SetReadDeadline(time.Time{})
+
+	var b [1]byte
+	_, err := c.Read(b[:])
+	if err == nil {
+		// the peer actually sent a byte while idle, which shouldn't
+		// happen for HTTP keepalive; treat it as dead rather than risk
+		// desyncing the next response off of it.
+		return false
+	}
+	if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		return true
+	}
+	return false
+}
+
+// put returns c to the pool for reuse, closing it instead if addr's
+// idle set is already at capacity.
+func (p *connPool) put(addr string, c net.Conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.idle[addr]) >= p.maxIdle {
+		c.Close()
+		return
+	}
+	p.idle[addr] = append(p.idle[addr], c)
+}
@@ -0,0 +1,56 @@
+package local
+
+import (
+	"net"
+	"testing"
+)
+
+func TestConnPoolPutGetRoundTrip(t *testing.T) {
+	p := newConnPool(2)
+
+	client, server := net.Pipe()
+	defer client.Close()
+
+	p.put("backend:1", server)
+
+	got := p.get("backend:1")
+	if got != server {
+		t.Fatalf("get returned %v, want the connection just put in", got)
+	}
+
+	if got := p.get("backend:1"); got != nil {
+		t.Fatalf("get on an empty pool returned %v, want nil", got)
+	}
+}
+
+func TestConnPoolGetDropsDeadConnections(t *testing.T) {
+	p := newConnPool(2)
+
+	client, server := net.Pipe()
+	p.put("backend:1", server)
+	client.Close() // the "backend" side goes away while idle
+
+	if got := p.get("backend:1"); got != nil {
+		t.Fatalf("get returned a connection its peer had closed: %v", got)
+	}
+}
+
+func TestConnPoolPutClosesOverCapacity(t *testing.T) {
+	p := newConnPool(1)
+
+	c1a, c1b := net.Pipe()
+	c2a, c2b := net.Pipe()
+	defer c1a.Close()
+	defer c2a.Close()
+
+	p.put("backend:1", c1b)
+	p.put("backend:1", c2b) // pool is already at maxIdle=1, so this one is closed
+
+	if _, err := c2a.Write([]byte("x")); err == nil {
+		t.Fatal("expected writing to the over-capacity connection's peer to fail")
+	}
+
+	if got := p.get("backend:1"); got != c1b {
+		t.Fatalf("get returned %v, want the connection kept under capacity", got)
+	}
+}
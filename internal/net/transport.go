@@ -0,0 +1,73 @@
+// Package net provides connection-pumping helpers shared by handlers
+// that proxy raw byte streams between a client and an upstream
+// connection.
+package net
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Transport pipes rw1 and rw2 to each other until one side returns an
+// error (including io.EOF) or ctx is done, then returns the first
+// non-EOF error seen.
+//
+// Both copy directions run in their own goroutine and always get to
+// send on errc, which is sized for both of them, so neither can block
+// forever on a send the other side has stopped listening for. As soon
+// as either direction finishes, the shared context is cancelled; a
+// watcher goroutine reacts to that by poking any side still blocked in
+// a Read or Write with SetDeadline(time.Now()) so it unblocks instead
+// of leaking until the OS-level timeout (or never).
+func Transport(ctx context.Context, rw1, rw2 io.ReadWriter) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errc := make(chan error, 2)
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	pipe := func(dst io.Writer, src io.Reader) {
+		defer wg.Done()
+		defer cancel()
+		errc <- CopyBuffer(dst, src, 32*1024)
+	}
+	go pipe(rw1, rw2)
+	go pipe(rw2, rw1)
+
+	go func() {
+		<-ctx.Done()
+		unblock(rw1)
+		unblock(rw2)
+	}()
+
+	wg.Wait()
+	close(errc)
+
+	var first error
+	for err := range errc {
+		if err != nil && err != io.EOF && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// unblock forces any Read or Write in flight on rw to return
+// immediately, so a copy goroutine blocked on one side of a Transport
+// doesn't keep running after the other side has already finished.
+func unblock(rw io.ReadWriter) {
+	if c, ok := rw.(net.Conn); ok {
+		c.SetDeadline(time.Now())
+	}
+}
+
+// CopyBuffer copies from src to dst using a buffer of size bufSize.
+func CopyBuffer(dst io.Writer, src io.Reader, bufSize int) error {
+	buf := make([]byte, bufSize)
+	_, err := io.CopyBuffer(dst, src, buf)
+	return err
+}
@@ -0,0 +1,94 @@
+package net
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestTransportCopiesBothDirections(t *testing.T) {
+	a1, a2 := net.Pipe()
+	b1, b2 := net.Pipe()
+	defer a1.Close()
+	defer b1.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- Transport(context.Background(), a2, b2)
+	}()
+
+	go func() {
+		a1.Write([]byte("ping"))
+	}()
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(b1, buf); err != nil || string(buf) != "ping" {
+		t.Fatalf("b1 read = %q, %v", buf, err)
+	}
+
+	go func() {
+		b1.Write([]byte("pong"))
+	}()
+	if _, err := io.ReadFull(a1, buf); err != nil || string(buf) != "pong" {
+		t.Fatalf("a1 read = %q, %v", buf, err)
+	}
+
+	a1.Close()
+	b1.Close()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Transport did not return after both sides closed")
+	}
+}
+
+// TestTransportUnblocksStuckSide ensures that when one copy direction
+// finishes (here because its peer closed), Transport still returns
+// promptly even though the other direction is blocked in a Read that
+// would otherwise never complete on its own.
+func TestTransportUnblocksStuckSide(t *testing.T) {
+	a1, a2 := net.Pipe()
+	b1, _ := net.Pipe()
+	defer b1.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- Transport(context.Background(), a2, b1)
+	}()
+
+	// Closing a1 makes a2's side return an error, finishing one copy
+	// direction. The other direction is blocked reading from b1, whose
+	// sibling nobody ever writes to or closes; only the
+	// cancellation-driven unblock() lets Transport return.
+	a1.Close()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Transport did not unblock the stuck side after the other side finished")
+	}
+}
+
+func TestTransportContextCancellation(t *testing.T) {
+	a1, a2 := net.Pipe()
+	b1, b2 := net.Pipe()
+	defer a1.Close()
+	defer b1.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- Transport(ctx, a2, b2)
+	}()
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Transport did not return after its context was cancelled")
+	}
+}
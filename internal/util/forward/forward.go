@@ -0,0 +1,69 @@
+// Package forward implements protocol sniffing for the forward handler.
+//
+// A Sniffer inspects the leading bytes of a connection and, if it
+// recognizes its protocol, returns routing metadata (destination host,
+// protocol name and any protocol-specific attributes) without consuming
+// bytes the upstream connection will still need. Built-in sniffers cover
+// TLS (ClientHello SNI), QUIC (the TLS ClientHello embedded in the first
+// Initial packet), SSH (the version banner) and HTTP/2 cleartext (the
+// connection preface and first HEADERS frame). Third parties can add
+// detectors for other protocols via registry.SnifferRegistry.
+package forward
+
+import (
+	"context"
+	"errors"
+)
+
+const (
+	ProtoHTTP = "http"
+	ProtoTLS  = "tls"
+	ProtoQUIC = "quic"
+	ProtoSSH  = "ssh"
+	ProtoH2C  = "h2c"
+)
+
+// ErrNotMatched is returned by a Sniffer when the supplied data does not
+// match the protocol it detects. It is not a fatal error: the caller
+// should try the next detector, since more data will not make this one
+// match.
+var ErrNotMatched = errors.New("forward: protocol not matched")
+
+// ErrNeedMore is returned by a Sniffer when it cannot yet tell whether
+// the data matches its protocol and more bytes are required.
+var ErrNeedMore = errors.New("forward: need more data")
+
+// Result is what a successful Sniff call reports back to the handler.
+type Result struct {
+	// Host is the destination host recovered from the sniffed data,
+	// e.g. the TLS SNI, the HTTP Host header or the HTTP/2 :authority.
+	Host string
+	// Protocol is one of the Proto* constants.
+	Protocol string
+	// Metadata carries protocol-specific attributes, e.g. the SSH
+	// client banner, for use as routing hints or log fields.
+	Metadata map[string]string
+}
+
+// Sniffer detects a single protocol from the leading bytes of a stream.
+type Sniffer interface {
+	// Name is the detector identifier, e.g. "tls", "quic", "ssh", "h2c".
+	Name() string
+	// Sniff inspects buf, the bytes read so far from the connection,
+	// and reports the detected protocol. It returns ErrNeedMore if buf
+	// is a valid-so-far prefix but too short to decide, and
+	// ErrNotMatched if buf can never match this protocol.
+	Sniff(ctx context.Context, buf []byte) (*Result, error)
+}
+
+// Sniffers returns the built-in stream-oriented detectors, in the order
+// they are tried. QUIC is packet-oriented and is sniffed separately via
+// SniffPacket.
+func Sniffers() []Sniffer {
+	return []Sniffer{
+		&tlsSniffer{},
+		&sshSniffer{},
+		&h2cSniffer{},
+		&httpSniffer{},
+	}
+}
@@ -0,0 +1,111 @@
+package forward
+
+import (
+	"bytes"
+	"context"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+// h2cPreface is the HTTP/2 connection preface a client sends before any
+// frames, identical for cleartext and TLS-negotiated HTTP/2 (RFC 9113
+// Section 3.4).
+var h2cPreface = []byte(http2.ClientPreface)
+
+// HEADERS frame flags (RFC 9113 Section 6.2).
+const (
+	flagEndHeaders = 0x4
+	flagPadded     = 0x8
+	flagPriority   = 0x20
+)
+
+// h2cSniffer detects HTTP/2 over cleartext TCP and recovers the
+// :authority pseudo-header from the first HEADERS frame.
+type h2cSniffer struct{}
+
+func (s *h2cSniffer) Name() string { return ProtoH2C }
+
+func (s *h2cSniffer) Sniff(ctx context.Context, buf []byte) (*Result, error) {
+	if len(buf) < len(h2cPreface) {
+		if !bytes.HasPrefix(h2cPreface, buf) {
+			return nil, ErrNotMatched
+		}
+		return nil, ErrNeedMore
+	}
+	if !bytes.HasPrefix(buf, h2cPreface) {
+		return nil, ErrNotMatched
+	}
+
+	rest := buf[len(h2cPreface):]
+	// frame header: length(3) type(1) flags(1) stream_id(4)
+	if len(rest) < 9 {
+		return nil, ErrNeedMore
+	}
+	length := int(rest[0])<<16 | int(rest[1])<<8 | int(rest[2])
+	frameType := rest[3]
+	flags := rest[4]
+	if frameType != 0x1 { // HEADERS; a SETTINGS frame may come first,
+		// in which case it is skipped once complete and the frame after
+		// it is sniffed instead. Any other leading frame type is
+		// not matched.
+		if frameType == 0x4 { // SETTINGS
+			if len(rest) < 9+length {
+				return nil, ErrNeedMore
+			}
+			return s.Sniff(ctx, append(append([]byte{}, buf[:len(h2cPreface)]...), rest[9+length:]...))
+		}
+		return nil, ErrNotMatched
+	}
+	if len(rest) < 9+length {
+		return nil, ErrNeedMore
+	}
+	if flags&flagEndHeaders == 0 {
+		// the header block continues in a CONTINUATION frame; this
+		// sniffer only looks at a single HEADERS frame and doesn't
+		// reassemble fragmented header blocks, so treat it as an
+		// unsupported shape rather than feed hpack a truncated block.
+		return nil, ErrNotMatched
+	}
+
+	payload := rest[9 : 9+length]
+	if flags&flagPadded != 0 {
+		if len(payload) < 1 {
+			return nil, ErrNotMatched
+		}
+		padLen := int(payload[0])
+		payload = payload[1:]
+		if padLen > len(payload) {
+			return nil, ErrNotMatched
+		}
+		payload = payload[:len(payload)-padLen]
+	}
+	if flags&flagPriority != 0 {
+		if len(payload) < 5 {
+			return nil, ErrNotMatched
+		}
+		payload = payload[5:] // stream dependency(4) + weight(1)
+	}
+
+	host, ok := parseAuthorityFromHeadersFrame(payload)
+	if !ok {
+		return nil, ErrNotMatched
+	}
+
+	return &Result{Host: host, Protocol: ProtoH2C}, nil
+}
+
+func parseAuthorityFromHeadersFrame(payload []byte) (string, bool) {
+	var authority string
+	var found bool
+	dec := hpack.NewDecoder(4096, func(f hpack.HeaderField) {
+		if f.Name == ":authority" {
+			authority = f.Value
+			found = true
+		}
+	})
+	if _, err := dec.Write(payload); err != nil {
+		return "", false
+	}
+	return authority, found
+}
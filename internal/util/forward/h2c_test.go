@@ -0,0 +1,134 @@
+package forward
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+func buildH2CPreface(t *testing.T, authority string) []byte {
+	t.Helper()
+
+	var headerBlock bytes.Buffer
+	enc := hpack.NewEncoder(&headerBlock)
+	if err := enc.WriteField(hpack.HeaderField{Name: ":authority", Value: authority}); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := headerBlock.Bytes()
+	frame := []byte{
+		byte(len(payload) >> 16), byte(len(payload) >> 8), byte(len(payload)),
+		0x1,                // HEADERS
+		flagEndHeaders,     // flags
+		0x0, 0x0, 0x0, 0x1, // stream id 1
+	}
+	frame = append(frame, payload...)
+
+	return append([]byte(http2.ClientPreface), frame...)
+}
+
+// buildH2CPrefaceNoEndHeaders is like buildH2CPreface but leaves the
+// END_HEADERS flag unset, as if the header block continued in a
+// CONTINUATION frame.
+func buildH2CPrefaceNoEndHeaders(t *testing.T, authority string) []byte {
+	t.Helper()
+
+	var headerBlock bytes.Buffer
+	enc := hpack.NewEncoder(&headerBlock)
+	if err := enc.WriteField(hpack.HeaderField{Name: ":authority", Value: authority}); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := headerBlock.Bytes()
+	frame := []byte{
+		byte(len(payload) >> 16), byte(len(payload) >> 8), byte(len(payload)),
+		0x1,                // HEADERS
+		0x0,                // flags: END_HEADERS not set
+		0x0, 0x0, 0x0, 0x1, // stream id 1
+	}
+	frame = append(frame, payload...)
+
+	return append([]byte(http2.ClientPreface), frame...)
+}
+
+// buildH2CPrefacePadded is like buildH2CPreface but sets PADDED and
+// includes trailing pad bytes, as real clients and intermediaries do.
+func buildH2CPrefacePadded(t *testing.T, authority string, padLen int) []byte {
+	t.Helper()
+
+	var headerBlock bytes.Buffer
+	enc := hpack.NewEncoder(&headerBlock)
+	if err := enc.WriteField(hpack.HeaderField{Name: ":authority", Value: authority}); err != nil {
+		t.Fatal(err)
+	}
+
+	hdrPayload := headerBlock.Bytes()
+	payload := append([]byte{byte(padLen)}, hdrPayload...)
+	payload = append(payload, make([]byte, padLen)...)
+
+	frame := []byte{
+		byte(len(payload) >> 16), byte(len(payload) >> 8), byte(len(payload)),
+		0x1,                         // HEADERS
+		flagEndHeaders | flagPadded, // flags
+		0x0, 0x0, 0x0, 0x1,          // stream id 1
+	}
+	frame = append(frame, payload...)
+
+	return append([]byte(http2.ClientPreface), frame...)
+}
+
+func TestH2CSnifferAuthority(t *testing.T) {
+	s := &h2cSniffer{}
+	buf := buildH2CPreface(t, "example.com")
+
+	result, err := s.Sniff(context.Background(), buf)
+	if err != nil {
+		t.Fatalf("Sniff: %v", err)
+	}
+	if result.Host != "example.com" {
+		t.Fatalf("host = %q, want %q", result.Host, "example.com")
+	}
+}
+
+func TestH2CSnifferNeedsMore(t *testing.T) {
+	s := &h2cSniffer{}
+	full := buildH2CPreface(t, "example.com")
+	for i := 1; i < len(full); i++ {
+		_, err := s.Sniff(context.Background(), full[:i])
+		if err != ErrNeedMore && err != nil {
+			t.Fatalf("prefix %d: got %v, want ErrNeedMore or nil", i, err)
+		}
+	}
+}
+
+func TestH2CSnifferNotMatched(t *testing.T) {
+	s := &h2cSniffer{}
+	if _, err := s.Sniff(context.Background(), []byte("GET / HTTP/1.1\r\n\r\n")); err != ErrNotMatched {
+		t.Fatalf("got %v, want ErrNotMatched", err)
+	}
+}
+
+func TestH2CSnifferPadded(t *testing.T) {
+	s := &h2cSniffer{}
+	buf := buildH2CPrefacePadded(t, "example.com", 8)
+
+	result, err := s.Sniff(context.Background(), buf)
+	if err != nil {
+		t.Fatalf("Sniff: %v", err)
+	}
+	if result.Host != "example.com" {
+		t.Fatalf("host = %q, want %q", result.Host, "example.com")
+	}
+}
+
+func TestH2CSnifferFragmentedHeadersNotSupported(t *testing.T) {
+	s := &h2cSniffer{}
+	buf := buildH2CPrefaceNoEndHeaders(t, "example.com")
+
+	if _, err := s.Sniff(context.Background(), buf); err != ErrNotMatched {
+		t.Fatalf("got %v, want ErrNotMatched for a HEADERS frame missing END_HEADERS", err)
+	}
+}
@@ -0,0 +1,56 @@
+package forward
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net/http"
+)
+
+// httpSniffer recovers the Host from a plaintext HTTP/1.x request line
+// and headers.
+type httpSniffer struct{}
+
+func (s *httpSniffer) Name() string { return ProtoHTTP }
+
+func (s *httpSniffer) Sniff(ctx context.Context, buf []byte) (*Result, error) {
+	i := bytes.Index(buf, []byte("\r\n\r\n"))
+	if i < 0 {
+		if !looksLikeHTTPRequestLine(buf) {
+			return nil, ErrNotMatched
+		}
+		// keep waiting for the header terminator; the caller (sniff.go)
+		// is the one that knows the configured buffer ceiling and will
+		// give up on our behalf once it's reached.
+		return nil, ErrNeedMore
+	}
+
+	req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(buf[:i+4])))
+	if err != nil {
+		return nil, ErrNotMatched
+	}
+
+	return &Result{Host: req.Host, Protocol: ProtoHTTP}, nil
+}
+
+// looksLikeHTTPRequestLine does a cheap prefix check against the HTTP
+// methods gost forwards for, so non-HTTP traffic fails fast instead of
+// buffering all the way up to the configured ceiling waiting for a
+// header terminator that will never come.
+func looksLikeHTTPRequestLine(buf []byte) bool {
+	for _, m := range []string{
+		"GET ", "HEAD ", "POST ", "PUT ", "DELETE ", "CONNECT ",
+		"OPTIONS ", "TRACE ", "PATCH ",
+	} {
+		if len(buf) >= len(m) {
+			if bytes.HasPrefix(buf, []byte(m)) {
+				return true
+			}
+			continue
+		}
+		if bytes.HasPrefix([]byte(m), buf) {
+			return true
+		}
+	}
+	return false
+}
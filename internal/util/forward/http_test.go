@@ -0,0 +1,37 @@
+package forward
+
+import (
+	"context"
+	"testing"
+)
+
+func TestHTTPSnifferHost(t *testing.T) {
+	s := &httpSniffer{}
+	buf := []byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n")
+
+	result, err := s.Sniff(context.Background(), buf)
+	if err != nil {
+		t.Fatalf("Sniff: %v", err)
+	}
+	if result.Host != "example.com" {
+		t.Fatalf("host = %q, want %q", result.Host, "example.com")
+	}
+}
+
+func TestHTTPSnifferNeedsMore(t *testing.T) {
+	s := &httpSniffer{}
+	full := []byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n")
+	for i := 1; i < len(full); i++ {
+		_, err := s.Sniff(context.Background(), full[:i])
+		if err != ErrNeedMore && err != nil {
+			t.Fatalf("prefix %d: got %v, want ErrNeedMore or nil", i, err)
+		}
+	}
+}
+
+func TestHTTPSnifferNotMatched(t *testing.T) {
+	s := &httpSniffer{}
+	if _, err := s.Sniff(context.Background(), []byte("\x16\x03\x01\x00\x00")); err != ErrNotMatched {
+		t.Fatalf("got %v, want ErrNotMatched", err)
+	}
+}
@@ -0,0 +1,260 @@
+package forward
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// quicInitialSalt is the version-specific salt used to derive the
+// Initial keys for QUIC v1 (RFC 9001 Section 5.2).
+var quicInitialSalt = []byte{
+	0x38, 0x76, 0x2c, 0xf7, 0xf5, 0x59, 0x34, 0xb3,
+	0x4d, 0x17, 0x9a, 0xe6, 0xa4, 0xc8, 0x0c, 0xad,
+	0xcc, 0xbb, 0x7f, 0x0a,
+}
+
+// SniffPacket inspects a single UDP datagram for a QUIC Initial packet
+// and, if found, removes header protection and decrypts it with the
+// public Initial secrets (these protect against off-path attacks, not
+// against a host on the path reading the handshake, so this is not a
+// capability gost is adding that an on-path sniffer didn't already
+// have) to recover the embedded TLS ClientHello's SNI.
+func SniffPacket(ctx context.Context, data []byte) (*Result, error) {
+	host, err := quicInitialSNI(data)
+	if err != nil {
+		return nil, ErrNotMatched
+	}
+	return &Result{Host: host, Protocol: ProtoQUIC}, nil
+}
+
+func quicInitialSNI(data []byte) (string, error) {
+	if len(data) < 7 || data[0]&0x80 == 0 || (data[0]&0x30)>>4 != 0 {
+		// not a long-header Initial packet
+		return "", errors.New("not a quic initial packet")
+	}
+
+	version := binary.BigEndian.Uint32(data[1:5])
+	if version == 0 {
+		return "", errors.New("version negotiation packet")
+	}
+
+	p := data[5:]
+	dcidLen := int(p[0])
+	p = p[1:]
+	if len(p) < dcidLen {
+		return "", errors.New("truncated dcid")
+	}
+	dcid := p[:dcidLen]
+	p = p[dcidLen:]
+
+	if len(p) < 1 {
+		return "", errors.New("truncated scid")
+	}
+	scidLen := int(p[0])
+	p = p[1:]
+	if len(p) < scidLen {
+		return "", errors.New("truncated scid")
+	}
+	p = p[scidLen:]
+
+	tokenLen, n, err := readVarint(p)
+	if err != nil {
+		return "", err
+	}
+	p = p[n:]
+	if len(p) < int(tokenLen) {
+		return "", errors.New("truncated token")
+	}
+	p = p[tokenLen:]
+
+	lengthHdrEnd := len(data) - len(p)
+	payloadLen, n, err := readVarint(p)
+	if err != nil {
+		return "", err
+	}
+	pnOffset := lengthHdrEnd + n
+
+	clientSecret := deriveInitialSecret(dcid)
+	hp, key, iv := quicInitialKeys(clientSecret)
+
+	cleartext, err := removeQUICHeaderProtection(data, pnOffset, hp)
+	if err != nil {
+		return "", err
+	}
+
+	pnLen := int(cleartext[0]&0x03) + 1
+	if payloadLen < uint64(pnLen) {
+		// the Length field covers the packet number plus payload, so
+		// it can never be smaller than the packet number alone; a
+		// packet claiming otherwise is malformed.
+		return "", errors.New("invalid initial packet length")
+	}
+	if pnOffset+pnLen > len(cleartext) {
+		return "", errors.New("truncated packet number")
+	}
+	packetNumber := cleartext[pnOffset : pnOffset+pnLen]
+
+	headerLen := pnOffset + pnLen
+	sampleEnd := pnOffset + int(payloadLen)
+	if sampleEnd > len(data) {
+		sampleEnd = len(data)
+	}
+	if sampleEnd < headerLen {
+		return "", errors.New("truncated initial packet")
+	}
+	ciphertext := cleartext[headerLen:sampleEnd]
+
+	plaintext, err := decryptQUICPayload(key, iv, packetNumber, cleartext[:headerLen], ciphertext)
+	if err != nil {
+		return "", err
+	}
+
+	ch, ok := extractClientHelloFromCryptoFrames(plaintext)
+	if !ok {
+		return "", errors.New("no crypto frame")
+	}
+
+	// handshake header: type(1) length(3)
+	if len(ch) < 4 || ch[0] != 0x01 { // ClientHello
+		return "", errors.New("no client hello")
+	}
+	sni, ok := parseClientHelloSNI(ch[4:])
+	if !ok {
+		return "", errors.New("no sni")
+	}
+	return sni, nil
+}
+
+// deriveInitialSecret implements RFC 9001 Section 5.2's
+// initial_secret/client_initial_secret derivation.
+func deriveInitialSecret(dcid []byte) []byte {
+	initialSecret := hkdfExtract(quicInitialSalt, dcid)
+	return hkdfExpandLabel(initialSecret, "client in", 32)
+}
+
+func quicInitialKeys(secret []byte) (hp, key, iv []byte) {
+	key = hkdfExpandLabel(secret, "quic key", 16)
+	iv = hkdfExpandLabel(secret, "quic iv", 12)
+	hp = hkdfExpandLabel(secret, "quic hp", 16)
+	return
+}
+
+func hkdfExtract(salt, ikm []byte) []byte {
+	r := hkdf.Extract(sha256.New, ikm, salt)
+	return r
+}
+
+// hkdfExpandLabel implements TLS 1.3's HKDF-Expand-Label (RFC 8446
+// Section 7.1) with the "tls13 " prefix QUIC reuses.
+func hkdfExpandLabel(secret []byte, label string, length int) []byte {
+	hkdfLabel := make([]byte, 0, 2+1+6+len(label)+1)
+	hkdfLabel = append(hkdfLabel, byte(length>>8), byte(length))
+	full := "tls13 " + label
+	hkdfLabel = append(hkdfLabel, byte(len(full)))
+	hkdfLabel = append(hkdfLabel, full...)
+	hkdfLabel = append(hkdfLabel, 0) // no context
+	out := make([]byte, length)
+	r := hkdf.Expand(sha256.New, secret, hkdfLabel)
+	r.Read(out)
+	return out
+}
+
+func removeQUICHeaderProtection(data []byte, pnOffset int, hp []byte) ([]byte, error) {
+	out := append([]byte(nil), data...)
+	if pnOffset+4+16 > len(out) {
+		return nil, errors.New("packet too short for sampling")
+	}
+	sample := out[pnOffset+4 : pnOffset+4+16]
+
+	block, err := aes.NewCipher(hp)
+	if err != nil {
+		return nil, err
+	}
+	mask := make([]byte, block.BlockSize())
+	block.Encrypt(mask, sample)
+
+	out[0] ^= mask[0] & 0x0f
+	pnLen := int(out[0]&0x03) + 1
+	for i := 0; i < pnLen; i++ {
+		out[pnOffset+i] ^= mask[1+i]
+	}
+	return out, nil
+}
+
+func decryptQUICPayload(key, iv, packetNumber, aad, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := append([]byte(nil), iv...)
+	for i := 0; i < len(packetNumber); i++ {
+		nonce[len(nonce)-len(packetNumber)+i] ^= packetNumber[i]
+	}
+
+	return aead.Open(nil, nonce, ciphertext, aad)
+}
+
+// extractClientHelloFromCryptoFrames scans decrypted Initial payload
+// frames for a CRYPTO frame (type 0x06) and returns its data, which for
+// the first Initial packet of a connection is the start of (and usually
+// the whole of) the TLS ClientHello.
+func extractClientHelloFromCryptoFrames(payload []byte) ([]byte, bool) {
+	for len(payload) > 0 {
+		switch payload[0] {
+		case 0x00: // PADDING
+			payload = payload[1:]
+			continue
+		case 0x06: // CRYPTO
+			payload = payload[1:]
+			offset, n, err := readVarint(payload)
+			if err != nil {
+				return nil, false
+			}
+			payload = payload[n:]
+			length, n, err := readVarint(payload)
+			if err != nil {
+				return nil, false
+			}
+			payload = payload[n:]
+			if int(length) > len(payload) {
+				length = uint64(len(payload))
+			}
+			if offset != 0 {
+				// not the start of the CRYPTO stream; bail rather
+				// than misparse a fragment as a full ClientHello.
+				return nil, false
+			}
+			return payload[:length], true
+		default:
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+func readVarint(b []byte) (uint64, int, error) {
+	if len(b) == 0 {
+		return 0, 0, errors.New("empty varint")
+	}
+	prefix := b[0] >> 6
+	length := 1 << prefix
+	if len(b) < length {
+		return 0, 0, errors.New("truncated varint")
+	}
+	v := uint64(b[0] & 0x3f)
+	for i := 1; i < length; i++ {
+		v = v<<8 | uint64(b[i])
+	}
+	return v, length, nil
+}
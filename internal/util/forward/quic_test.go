@@ -0,0 +1,189 @@
+package forward
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"testing"
+)
+
+func encodeVarint(n int) []byte {
+	v := uint64(n)
+	switch {
+	case v < 64:
+		return []byte{byte(v)}
+	case v < 16384:
+		b := make([]byte, 2)
+		binary.BigEndian.PutUint16(b, uint16(v)|0x4000)
+		return b
+	case v < 1073741824:
+		b := make([]byte, 4)
+		binary.BigEndian.PutUint32(b, uint32(v)|0x80000000)
+		return b
+	default:
+		b := make([]byte, 8)
+		binary.BigEndian.PutUint64(b, v|0xc000000000000000)
+		return b
+	}
+}
+
+// buildClientHelloBody returns a minimal TLS 1.3 ClientHello (handshake
+// header included) carrying sni as its server_name extension.
+func buildClientHelloBody(sni string) []byte {
+	var body []byte
+	body = append(body, 0x03, 0x03)          // legacy_version
+	body = append(body, make([]byte, 32)...) // random
+	body = append(body, 0x00)                // session_id, empty
+	body = append(body, 0x00, 0x02, 0x13, 0x01)
+	body = append(body, 0x01, 0x00) // compression methods
+
+	name := []byte(sni)
+	sn := append([]byte{0x00, byte(len(name) >> 8), byte(len(name))}, name...)
+	snl := append([]byte{byte(len(sn) >> 8), byte(len(sn))}, sn...)
+	ext := append([]byte{0x00, 0x00, byte(len(snl) >> 8), byte(len(snl))}, snl...)
+
+	body = append(body, byte(len(ext)>>8), byte(len(ext)))
+	body = append(body, ext...)
+
+	hs := []byte{0x01, byte(len(body) >> 16), byte(len(body) >> 8), byte(len(body))}
+	return append(hs, body...)
+}
+
+// buildQUICInitial assembles and protects a QUIC v1 Initial packet
+// carrying clientHello in a single CRYPTO frame, the inverse of what
+// quicInitialSNI decodes.
+func buildQUICInitial(t *testing.T, dcid []byte, clientHello []byte) []byte {
+	t.Helper()
+
+	frame := []byte{0x06, 0x00} // CRYPTO, offset=0
+	frame = append(frame, encodeVarint(len(clientHello))...)
+	frame = append(frame, clientHello...)
+
+	const pnLen = 1
+	packetNumber := []byte{0x01}
+
+	header := []byte{0xc0 | byte(pnLen-1)}
+	header = append(header, 0x00, 0x00, 0x00, 0x01) // version 1
+	header = append(header, byte(len(dcid)))
+	header = append(header, dcid...)
+	header = append(header, 0x00) // scid len 0
+	header = append(header, 0x00) // token len 0
+	// Length covers the packet number and the protected payload,
+	// including its 16-byte AEAD tag.
+	header = append(header, encodeVarint(pnLen+len(frame)+16)...)
+
+	aad := append(append([]byte{}, header...), packetNumber...)
+
+	secret := deriveInitialSecret(dcid)
+	hp, key, iv := quicInitialKeys(secret)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := append([]byte{}, iv...)
+	nonce[len(nonce)-1] ^= packetNumber[0]
+	ciphertext := aead.Seal(nil, nonce, frame, aad)
+
+	pkt := append(append([]byte{}, header...), packetNumber...)
+	pkt = append(pkt, ciphertext...)
+
+	pnOffset := len(header)
+	sample := pkt[pnOffset+4 : pnOffset+4+16]
+	hpBlock, err := aes.NewCipher(hp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mask := make([]byte, hpBlock.BlockSize())
+	hpBlock.Encrypt(mask, sample)
+
+	pkt[0] ^= mask[0] & 0x0f
+	for i := 0; i < pnLen; i++ {
+		pkt[pnOffset+i] ^= mask[1+i]
+	}
+
+	return pkt
+}
+
+func TestSniffPacketQUICRoundTrip(t *testing.T) {
+	dcid := []byte{0x83, 0x94, 0xc8, 0xf0, 0x3e, 0x51, 0x57, 0x08}
+	pkt := buildQUICInitial(t, dcid, buildClientHelloBody("example.com"))
+
+	result, err := SniffPacket(context.Background(), pkt)
+	if err != nil {
+		t.Fatalf("SniffPacket: %v", err)
+	}
+	if result.Protocol != ProtoQUIC {
+		t.Fatalf("protocol = %q, want %q", result.Protocol, ProtoQUIC)
+	}
+	if result.Host != "example.com" {
+		t.Fatalf("host = %q, want %q", result.Host, "example.com")
+	}
+}
+
+// TestSniffPacketShortLengthField is the regression case for the crash
+// a maintainer reported: a long-header Initial packet whose Length
+// field (here a 1-byte varint of 0) is smaller than the packet number
+// it's supposed to cover, which used to panic with a negative slice
+// bound instead of being rejected as malformed.
+func TestSniffPacketShortLengthField(t *testing.T) {
+	header := []byte{
+		0xc0,                   // long header, fixed bit, type=Initial, pn len-1=0
+		0x00, 0x00, 0x00, 0x01, // version 1
+		0x00, // dcid len 0
+		0x00, // scid len 0
+		0x00, // token len varint = 0
+		0x00, // length varint = 0: smaller than the 1-byte packet number
+	}
+	data := append(header, make([]byte, 24)...) // room for the header-protection sample
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("SniffPacket panicked: %v", r)
+		}
+	}()
+
+	if _, err := SniffPacket(context.Background(), data); err == nil {
+		t.Fatal("expected an error for a malformed length field, got nil")
+	}
+}
+
+// TestSniffPacketTruncatedNoPanic feeds every prefix of a valid Initial
+// packet (and a few further-corrupted variants) through SniffPacket and
+// requires it to never panic, only ever return an error.
+func TestSniffPacketTruncatedNoPanic(t *testing.T) {
+	dcid := []byte{0x83, 0x94, 0xc8, 0xf0, 0x3e, 0x51, 0x57, 0x08}
+	valid := buildQUICInitial(t, dcid, buildClientHelloBody("example.com"))
+
+	run := func(name string, data []byte) {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("SniffPacket panicked on %q: %v", name, r)
+				}
+			}()
+			_, _ = SniffPacket(context.Background(), data)
+		})
+	}
+
+	for i := 0; i <= len(valid); i++ {
+		run("prefix", append([]byte{}, valid[:i]...))
+	}
+
+	run("empty", nil)
+	run("short_header", []byte{0xc0, 0x00})
+
+	versionNegotiation := append([]byte{}, valid...)
+	versionNegotiation[1], versionNegotiation[2] = 0, 0
+	versionNegotiation[3], versionNegotiation[4] = 0, 0
+	run("version_negotiation", versionNegotiation)
+
+	shortHeader := append([]byte{}, valid...)
+	shortHeader[0] &^= 0x80 // clear the long-header bit
+	run("not_long_header", shortHeader)
+}
@@ -0,0 +1,104 @@
+package forward
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"time"
+)
+
+// Options controls how Sniffing reads off the connection before giving
+// up on detection.
+type Options struct {
+	// MaxBufferSize bounds how many bytes are buffered while probing.
+	// Detectors that still return ErrNeedMore past this limit cause
+	// sniffing to give up and fall back to unsniffed forwarding.
+	MaxBufferSize int
+	// Timeout bounds how long Sniffing waits for enough bytes to reach
+	// a verdict. Zero means no per-read deadline is applied.
+	Timeout time.Duration
+	// Sniffers overrides the set of detectors to try, in order.
+	// Defaults to Sniffers().
+	Sniffers []Sniffer
+}
+
+const defaultMaxBufferSize = 16 * 1024
+
+// rewindConn wraps a net.Conn so that bytes consumed while probing can be
+// replayed to the eventual reader, i.e. the downstream pipe sees exactly
+// the bytes the client sent, in order.
+type rewindConn struct {
+	net.Conn
+	buf *bytes.Buffer
+}
+
+func (c *rewindConn) Read(p []byte) (int, error) {
+	if c.buf.Len() > 0 {
+		n, _ := c.buf.Read(p)
+		return n, nil
+	}
+	return c.Conn.Read(p)
+}
+
+// Sniffing probes conn for a recognized protocol using opts.Sniffers (or
+// the built-in set if nil). It returns an io.ReadWriter that replays any
+// bytes it consumed while probing, so callers can treat it as conn from
+// here on regardless of whether sniffing succeeded. On timeout or when
+// no detector matches within MaxBufferSize, it returns the rewound
+// stream with an empty host/protocol so the caller can fall back to
+// unsniffed forwarding.
+func Sniffing(ctx context.Context, conn net.Conn, opts *Options) (rw io.ReadWriter, result *Result, err error) {
+	o := Options{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.MaxBufferSize <= 0 {
+		o.MaxBufferSize = defaultMaxBufferSize
+	}
+	sniffers := o.Sniffers
+	if sniffers == nil {
+		sniffers = Sniffers()
+	}
+
+	if o.Timeout > 0 {
+		conn.SetReadDeadline(time.Now().Add(o.Timeout))
+		defer conn.SetReadDeadline(time.Time{})
+	}
+
+	buf := make([]byte, 0, o.MaxBufferSize)
+	tmp := make([]byte, 1024)
+
+	for {
+		n, rerr := conn.Read(tmp)
+		if n > 0 {
+			buf = append(buf, tmp[:n]...)
+		}
+		if len(buf) == 0 {
+			if rerr != nil {
+				break
+			}
+			continue
+		}
+
+		pending := false
+		for _, s := range sniffers {
+			r, serr := s.Sniff(ctx, buf)
+			switch serr {
+			case nil:
+				return &rewindConn{Conn: conn, buf: bytes.NewBuffer(buf)}, r, nil
+			case ErrNeedMore:
+				pending = true
+			}
+		}
+
+		if rerr != nil || !pending || len(buf) >= o.MaxBufferSize {
+			// timeout/EOF, every detector rejected outright, or we've
+			// buffered as much as we're willing to: give up and let
+			// the caller fall back to unsniffed forwarding.
+			break
+		}
+	}
+
+	return &rewindConn{Conn: conn, buf: bytes.NewBuffer(buf)}, &Result{}, nil
+}
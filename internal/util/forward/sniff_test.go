@@ -0,0 +1,82 @@
+package forward
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSniffingHTTP(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	go func() {
+		client.Write([]byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+	}()
+
+	rw, result, err := Sniffing(context.Background(), server, nil)
+	if err != nil {
+		t.Fatalf("Sniffing: %v", err)
+	}
+	if result.Protocol != ProtoHTTP || result.Host != "example.com" {
+		t.Fatalf("result = %+v", result)
+	}
+
+	// the consumed bytes must be replayed verbatim for the caller.
+	buf := make([]byte, 64)
+	n, err := rw.Read(buf)
+	if err != nil {
+		t.Fatalf("replayed read: %v", err)
+	}
+	if got := string(buf[:n]); got[:3] != "GET" {
+		t.Fatalf("replayed bytes = %q, want prefix GET", got)
+	}
+}
+
+func TestSniffingFallsBackOnUnrecognizedInput(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	go func() {
+		client.Write([]byte("not a recognized protocol"))
+	}()
+
+	rw, result, err := Sniffing(context.Background(), server, &Options{Timeout: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Sniffing: %v", err)
+	}
+	if result.Protocol != "" || result.Host != "" {
+		t.Fatalf("expected an empty result, got %+v", result)
+	}
+	if rw == nil {
+		t.Fatal("expected a non-nil rewound reader even on fallback")
+	}
+}
+
+func TestSniffingTimesOutOnSilentPeer(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, result, err := Sniffing(context.Background(), server, &Options{Timeout: 50 * time.Millisecond})
+		if err != nil {
+			t.Errorf("Sniffing: %v", err)
+			return
+		}
+		if result.Protocol != "" {
+			t.Errorf("expected empty protocol on timeout, got %q", result.Protocol)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Sniffing did not return after its timeout elapsed")
+	}
+}
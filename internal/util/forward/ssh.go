@@ -0,0 +1,48 @@
+package forward
+
+import (
+	"bytes"
+	"context"
+	"strings"
+)
+
+// sshIdentPrefix is the identification string prefix every SSH-2.0 peer
+// sends first, per RFC 4253 Section 4.2.
+const sshIdentPrefix = "SSH-2.0-"
+
+// sshSniffer detects the SSH version banner. It never recovers a host (SSH has no equivalent of SNI); it exists so the
+// handler can route on protocol and record the peer's banner/version as
+// metadata.
+type sshSniffer struct{}
+
+func (s *sshSniffer) Name() string { return ProtoSSH }
+
+func (s *sshSniffer) Sniff(ctx context.Context, buf []byte) (*Result, error) {
+	if len(buf) < len(sshIdentPrefix) {
+		if !bytes.HasPrefix([]byte(sshIdentPrefix), buf) {
+			return nil, ErrNotMatched
+		}
+		return nil, ErrNeedMore
+	}
+	if !bytes.HasPrefix(buf, []byte(sshIdentPrefix)) {
+		return nil, ErrNotMatched
+	}
+
+	i := bytes.IndexByte(buf, '\n')
+	if i < 0 {
+		if len(buf) > 255 { // RFC 4253 4.2: max identification string length
+			return nil, ErrNotMatched
+		}
+		return nil, ErrNeedMore
+	}
+
+	banner := strings.TrimRight(string(buf[:i]), "\r\n")
+
+	return &Result{
+		Protocol: ProtoSSH,
+		Metadata: map[string]string{
+			"banner":  banner,
+			"version": strings.TrimPrefix(banner, sshIdentPrefix),
+		},
+	}, nil
+}
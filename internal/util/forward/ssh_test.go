@@ -0,0 +1,52 @@
+package forward
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSSHSnifferBanner(t *testing.T) {
+	s := &sshSniffer{}
+	buf := []byte("SSH-2.0-OpenSSH_9.6\r\n")
+
+	result, err := s.Sniff(context.Background(), buf)
+	if err != nil {
+		t.Fatalf("Sniff: %v", err)
+	}
+	if result.Protocol != ProtoSSH {
+		t.Fatalf("protocol = %q, want %q", result.Protocol, ProtoSSH)
+	}
+	if result.Metadata["version"] != "OpenSSH_9.6" {
+		t.Fatalf("version = %q, want %q", result.Metadata["version"], "OpenSSH_9.6")
+	}
+}
+
+func TestSSHSnifferNeedsMore(t *testing.T) {
+	s := &sshSniffer{}
+	full := []byte("SSH-2.0-OpenSSH_9.6\r\n")
+	for i := 1; i < len(full); i++ {
+		if _, err := s.Sniff(context.Background(), full[:i]); err != ErrNeedMore {
+			t.Fatalf("prefix %d: got %v, want ErrNeedMore", i, err)
+		}
+	}
+}
+
+func TestSSHSnifferNotMatched(t *testing.T) {
+	s := &sshSniffer{}
+	if _, err := s.Sniff(context.Background(), []byte("GET / HTTP/1.1\r\n")); err != ErrNotMatched {
+		t.Fatalf("got %v, want ErrNotMatched", err)
+	}
+}
+
+func TestSSHSnifferOverlongBanner(t *testing.T) {
+	s := &sshSniffer{}
+	buf := []byte("SSH-2.0-" + strings.Repeat("x", 300))
+	if bytes.ContainsRune(buf, '\n') {
+		t.Fatal("test banner should not contain a newline")
+	}
+	if _, err := s.Sniff(context.Background(), buf); err != ErrNotMatched {
+		t.Fatalf("got %v, want ErrNotMatched for an overlong banner", err)
+	}
+}
@@ -0,0 +1,139 @@
+package forward
+
+import (
+	"context"
+	"encoding/binary"
+)
+
+// tlsSniffer recovers the SNI from a TLS ClientHello without doing a TLS
+// handshake: it walks the record/handshake/extension framing by hand so
+// the bytes can still be handed, untouched, to the real TLS stack
+// downstream.
+type tlsSniffer struct{}
+
+func (s *tlsSniffer) Name() string { return ProtoTLS }
+
+func (s *tlsSniffer) Sniff(ctx context.Context, buf []byte) (*Result, error) {
+	// TLS record header: type(1) version(2) length(2)
+	if len(buf) < 5 {
+		return nil, ErrNeedMore
+	}
+	if buf[0] != 0x16 { // handshake
+		return nil, ErrNotMatched
+	}
+	recLen := int(binary.BigEndian.Uint16(buf[3:5]))
+	if len(buf) < 5+recLen {
+		// keep waiting for the rest of the record; the caller (sniff.go)
+		// owns the configured buffer ceiling and will give up on our
+		// behalf once it's reached, so we don't hardcode one here.
+		return nil, ErrNeedMore
+	}
+
+	hs := buf[5 : 5+recLen]
+	// handshake header: type(1) length(3)
+	if len(hs) < 4 || hs[0] != 0x01 { // ClientHello
+		return nil, ErrNotMatched
+	}
+	body := hs[4:]
+
+	sni, ok := parseClientHelloSNI(body)
+	if !ok {
+		return nil, ErrNotMatched
+	}
+
+	return &Result{Host: sni, Protocol: ProtoTLS}, nil
+}
+
+// parseClientHelloSNI extracts the server_name extension value from the
+// body of a TLS ClientHello (everything after the 4-byte handshake
+// header). It returns ok=false if the body is malformed or carries no
+// SNI extension, in which case the caller should not treat it as a
+// partial match: a ClientHello this repo decided to sniff is expected to
+// be complete within one TLS record.
+func parseClientHelloSNI(b []byte) (string, bool) {
+	// legacy_version(2) + random(32)
+	if len(b) < 34 {
+		return "", false
+	}
+	b = b[34:]
+
+	// session_id
+	if len(b) < 1 {
+		return "", false
+	}
+	n := int(b[0])
+	if len(b) < 1+n {
+		return "", false
+	}
+	b = b[1+n:]
+
+	// cipher_suites
+	if len(b) < 2 {
+		return "", false
+	}
+	n = int(binary.BigEndian.Uint16(b[:2]))
+	if len(b) < 2+n {
+		return "", false
+	}
+	b = b[2+n:]
+
+	// compression_methods
+	if len(b) < 1 {
+		return "", false
+	}
+	n = int(b[0])
+	if len(b) < 1+n {
+		return "", false
+	}
+	b = b[1+n:]
+
+	// extensions
+	if len(b) < 2 {
+		return "", false
+	}
+	extLen := int(binary.BigEndian.Uint16(b[:2]))
+	b = b[2:]
+	if len(b) < extLen {
+		return "", false
+	}
+	b = b[:extLen]
+
+	for len(b) >= 4 {
+		extType := binary.BigEndian.Uint16(b[:2])
+		l := int(binary.BigEndian.Uint16(b[2:4]))
+		if len(b) < 4+l {
+			return "", false
+		}
+		data := b[4 : 4+l]
+		if extType == 0x0000 { // server_name
+			return parseServerNameList(data)
+		}
+		b = b[4+l:]
+	}
+
+	return "", false
+}
+
+func parseServerNameList(b []byte) (string, bool) {
+	if len(b) < 2 {
+		return "", false
+	}
+	listLen := int(binary.BigEndian.Uint16(b[:2]))
+	b = b[2:]
+	if len(b) < listLen {
+		return "", false
+	}
+	for len(b) >= 3 {
+		nameType := b[0]
+		l := int(binary.BigEndian.Uint16(b[1:3]))
+		if len(b) < 3+l {
+			return "", false
+		}
+		name := b[3 : 3+l]
+		if nameType == 0x00 { // host_name
+			return string(name), true
+		}
+		b = b[3+l:]
+	}
+	return "", false
+}
@@ -0,0 +1,55 @@
+package forward
+
+import (
+	"context"
+	"testing"
+)
+
+func buildClientHelloRecord(sni string) []byte {
+	body := buildClientHelloBody(sni)
+	rec := []byte{0x16, 0x03, 0x01, byte(len(body) >> 8), byte(len(body))}
+	return append(rec, body...)
+}
+
+func TestTLSSnifferSNI(t *testing.T) {
+	s := &tlsSniffer{}
+	buf := buildClientHelloRecord("example.com")
+
+	result, err := s.Sniff(context.Background(), buf)
+	if err != nil {
+		t.Fatalf("Sniff: %v", err)
+	}
+	if result.Host != "example.com" {
+		t.Fatalf("host = %q, want %q", result.Host, "example.com")
+	}
+}
+
+func TestTLSSnifferNeedsMore(t *testing.T) {
+	s := &tlsSniffer{}
+	full := buildClientHelloRecord("example.com")
+
+	for i := 1; i < len(full); i++ {
+		_, err := s.Sniff(context.Background(), full[:i])
+		if err != ErrNeedMore && err != nil {
+			t.Fatalf("prefix %d: got %v, want ErrNeedMore or a match", i, err)
+		}
+	}
+}
+
+func TestTLSSnifferNotMatched(t *testing.T) {
+	s := &tlsSniffer{}
+	cases := []struct {
+		buf  []byte
+		want error
+	}{
+		{[]byte{}, ErrNeedMore}, // shorter than a record header: can't decide yet
+		{[]byte{0x00, 0x00, 0x00, 0x00, 0x00}, ErrNotMatched},
+		{[]byte("GET / HTTP/1.1\r\n\r\n"), ErrNotMatched},
+	}
+	for _, c := range cases {
+		_, err := s.Sniff(context.Background(), c.buf)
+		if err != c.want {
+			t.Fatalf("got %v for %q, want %v", err, c.buf, c.want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package hosts
+
+import (
+	"context"
+	"errors"
+)
+
+// DynamicMapper is a Mapper kept in sync with a Loader: it loads once
+// synchronously so it's usable immediately, then reloads in the
+// background every time the Loader reports (or suspects) a change.
+type DynamicMapper struct {
+	*Mapper
+	cancel context.CancelFunc
+}
+
+// Open builds a DynamicMapper for rawURL ("file://" or a bare path for
+// the local filesystem, "http://"/"https://" for a remote source), does
+// an initial synchronous load, and starts watching for changes in the
+// background. Callers are expected to register the result under a name
+// via registry.HostsRegistry().Register so hops can reference it.
+func Open(rawURL string) (*DynamicMapper, error) {
+	loader, err := openLoader(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &DynamicMapper{Mapper: NewMapper()}
+	if err := m.reload(loader); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	go loader.Watch(ctx, func() {
+		_ = m.reload(loader)
+	})
+
+	return m, nil
+}
+
+func (m *DynamicMapper) reload(loader Loader) error {
+	rc, err := loader.Load()
+	if err != nil {
+		if errors.Is(err, errNotModified) {
+			return nil
+		}
+		return err
+	}
+	defer rc.Close()
+
+	return m.Mapper.Reload(rc)
+}
+
+// Close stops the background watch. It does not affect lookups already
+// served from the last loaded table.
+func (m *DynamicMapper) Close() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+}
@@ -0,0 +1,179 @@
+// Package hosts implements a dynamically reloadable, /etc/hosts-style
+// HostMapper: entries are loaded from a file or URL in RFC-952/1123
+// format (`IP name [aliases...]`), support glob names (`*.internal`)
+// and an optional per-entry TTL via a trailing `# ttl=30s` comment, and
+// are reloaded whenever the source changes.
+package hosts
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type record struct {
+	ips       []net.IP
+	expiresAt time.Time // zero means no TTL
+}
+
+func (r *record) expired(now time.Time) bool {
+	return !r.expiresAt.IsZero() && now.After(r.expiresAt)
+}
+
+// Table is a parsed snapshot of a hosts file: an immutable value so a
+// reload can swap it in without locking lookups against in-flight
+// parsing.
+type Table struct {
+	exact    map[string]*record
+	wildcard map[string]*record // pattern -> record, matched via path.Match
+	addrs    map[string][]string
+}
+
+// Parse reads hosts-file entries from r.
+func Parse(r io.Reader) (*Table, error) {
+	t := &Table{
+		exact:    make(map[string]*record),
+		wildcard: make(map[string]*record),
+		addrs:    make(map[string][]string),
+	}
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		ttl, rest := parseTTLComment(line)
+		fields := strings.Fields(rest)
+		if len(fields) < 2 {
+			continue
+		}
+
+		ip := net.ParseIP(fields[0])
+		if ip == nil {
+			continue
+		}
+
+		rec := &record{ips: []net.IP{ip}}
+		if ttl > 0 {
+			rec.expiresAt = time.Now().Add(ttl)
+		}
+
+		for _, name := range fields[1:] {
+			name = strings.ToLower(name)
+			if strings.ContainsAny(name, "*?[") {
+				t.wildcard[name] = rec
+				// a glob is not a real name: it can't be the
+				// "canonical name" LookupAddr reports back for a
+				// sniffed destination IP, so it doesn't go in addrs.
+				continue
+			}
+			if existing, ok := t.exact[name]; ok {
+				// same name repeated on an earlier line: accumulate
+				// IPs rather than letting the later line win outright.
+				t.exact[name] = &record{ips: append(existing.ips, ip), expiresAt: rec.expiresAt}
+			} else {
+				t.exact[name] = rec
+			}
+			t.addrs[ip.String()] = append(t.addrs[ip.String()], name)
+		}
+	}
+
+	return t, s.Err()
+}
+
+// parseTTLComment splits a trailing "# ttl=<duration>" comment off line
+// and returns the parsed duration (zero if absent or invalid) along
+// with the remaining entry text.
+func parseTTLComment(line string) (time.Duration, string) {
+	i := strings.Index(line, "#")
+	if i < 0 {
+		return 0, line
+	}
+	entry, comment := line[:i], strings.TrimSpace(line[i+1:])
+	for _, field := range strings.Fields(comment) {
+		if v, ok := strings.CutPrefix(field, "ttl="); ok {
+			if d, err := time.ParseDuration(v); err == nil {
+				return d, entry
+			}
+			if n, err := strconv.Atoi(v); err == nil {
+				return time.Duration(n) * time.Second, entry
+			}
+		}
+	}
+	return 0, entry
+}
+
+func (t *Table) lookup(host string) ([]net.IP, bool) {
+	host = strings.ToLower(host)
+	now := time.Now()
+
+	if rec, ok := t.exact[host]; ok && !rec.expired(now) {
+		return rec.ips, true
+	}
+	for pattern, rec := range t.wildcard {
+		if rec.expired(now) {
+			continue
+		}
+		if ok, _ := path.Match(pattern, host); ok {
+			return rec.ips, true
+		}
+	}
+	return nil, false
+}
+
+func (t *Table) lookupAddr(ip net.IP) ([]string, bool) {
+	names, ok := t.addrs[ip.String()]
+	return names, ok
+}
+
+// Mapper is a hosts.HostMapper (per github.com/go-gost/core/hosts) whose
+// table can be swapped out at any time via Reload, so a Loader can keep
+// it in sync with its source.
+type Mapper struct {
+	mu    sync.RWMutex
+	table *Table
+}
+
+// NewMapper creates an empty Mapper; call Reload (directly, or by
+// driving a Loader with Watch) to populate it.
+func NewMapper() *Mapper {
+	return &Mapper{table: &Table{
+		exact:    map[string]*record{},
+		wildcard: map[string]*record{},
+		addrs:    map[string][]string{},
+	}}
+}
+
+// Reload parses r and atomically replaces the mapper's table.
+func (m *Mapper) Reload(r io.Reader) error {
+	table, err := Parse(r)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.table = table
+	m.mu.Unlock()
+	return nil
+}
+
+// Lookup implements hosts.HostMapper.
+func (m *Mapper) Lookup(network, host string) ([]net.IP, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.table.lookup(host)
+}
+
+// LookupAddr resolves ip back to the name(s) it was configured under,
+// for annotating logs with the canonical name of a sniffed destination.
+func (m *Mapper) LookupAddr(ip net.IP) ([]string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.table.lookupAddr(ip)
+}
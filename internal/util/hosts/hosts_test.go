@@ -0,0 +1,127 @@
+package hosts
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseExactLookup(t *testing.T) {
+	table, err := Parse(strings.NewReader("10.0.0.1 foo.internal\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	ips, ok := table.lookup("foo.internal")
+	if !ok || len(ips) != 1 || !ips[0].Equal(net.ParseIP("10.0.0.1")) {
+		t.Fatalf("lookup(foo.internal) = %v, %v", ips, ok)
+	}
+}
+
+func TestParseWildcard(t *testing.T) {
+	table, err := Parse(strings.NewReader("10.0.0.2 *.internal\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	ips, ok := table.lookup("anything.internal")
+	if !ok || len(ips) != 1 || !ips[0].Equal(net.ParseIP("10.0.0.2")) {
+		t.Fatalf("lookup(anything.internal) = %v, %v", ips, ok)
+	}
+
+	if _, ok := table.lookup("internal"); ok {
+		t.Fatal("bare suffix should not match *.internal")
+	}
+}
+
+func TestParseTTLExpiry(t *testing.T) {
+	table, err := Parse(strings.NewReader("10.0.0.3 ephemeral.internal # ttl=10ms\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if _, ok := table.lookup("ephemeral.internal"); !ok {
+		t.Fatal("expected a fresh entry to be found")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := table.lookup("ephemeral.internal"); ok {
+		t.Fatal("expected the entry to have expired")
+	}
+}
+
+func TestParseIgnoresCommentsAndBlankLines(t *testing.T) {
+	table, err := Parse(strings.NewReader("# comment\n\n10.0.0.4 bar.internal\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if _, ok := table.lookup("bar.internal"); !ok {
+		t.Fatal("expected bar.internal to be parsed despite surrounding comments/blank lines")
+	}
+}
+
+func TestParseSkipsMalformedLines(t *testing.T) {
+	table, err := Parse(strings.NewReader("not-an-ip name\nonly-one-field\n10.0.0.5 ok.internal\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if _, ok := table.lookup("ok.internal"); !ok {
+		t.Fatal("expected the valid entry to survive malformed neighbors")
+	}
+}
+
+func TestLookupAddrExcludesWildcards(t *testing.T) {
+	table, err := Parse(strings.NewReader(
+		"10.0.0.6 exact.internal\n10.0.0.6 *.internal\n",
+	))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	names, ok := table.lookupAddr(net.ParseIP("10.0.0.6"))
+	if !ok {
+		t.Fatal("expected a reverse lookup hit")
+	}
+	for _, n := range names {
+		if strings.ContainsAny(n, "*?[") {
+			t.Fatalf("LookupAddr returned a glob pattern as a canonical name: %q", n)
+		}
+	}
+	if len(names) != 1 || names[0] != "exact.internal" {
+		t.Fatalf("names = %v, want [exact.internal]", names)
+	}
+}
+
+func TestMapperReloadIsAtomic(t *testing.T) {
+	m := NewMapper()
+	if err := m.Reload(strings.NewReader("10.0.0.7 v1.internal\n")); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if _, ok := m.Lookup("ip", "v1.internal"); !ok {
+		t.Fatal("expected v1.internal to resolve after first load")
+	}
+
+	if err := m.Reload(strings.NewReader("10.0.0.8 v2.internal\n")); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if _, ok := m.Lookup("ip", "v1.internal"); ok {
+		t.Fatal("expected v1.internal to be gone after reload")
+	}
+	ips, ok := m.Lookup("ip", "v2.internal")
+	if !ok || !ips[0].Equal(net.ParseIP("10.0.0.8")) {
+		t.Fatalf("lookup(v2.internal) = %v, %v", ips, ok)
+	}
+}
+
+func TestMapperLookupAddr(t *testing.T) {
+	m := NewMapper()
+	if err := m.Reload(strings.NewReader("10.0.0.9 rev.internal\n")); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	names, ok := m.LookupAddr(net.ParseIP("10.0.0.9"))
+	if !ok || len(names) != 1 || names[0] != "rev.internal" {
+		t.Fatalf("LookupAddr = %v, %v", names, ok)
+	}
+}
@@ -0,0 +1,187 @@
+package hosts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultPollInterval is used by loaders that have no better signal for
+// change (e.g. an HTTP source with no caching headers) and as the
+// polling fallback when a file loader can't establish an fsnotify
+// watch.
+const DefaultPollInterval = 30 * time.Second
+
+// errNotModified is returned by Loader.Load when the source reports
+// (e.g. via ETag) that it has not changed since the last Load call; it
+// is not an error Watch should propagate, just a no-op reload.
+var errNotModified = errors.New("hosts: not modified")
+
+// Loader fetches the raw contents of a hosts source and can notify a
+// caller when that source changes.
+type Loader interface {
+	// Load fetches the current contents. It returns errNotModified if
+	// the source is unchanged since the previous call.
+	Load() (io.ReadCloser, error)
+	// Watch blocks, invoking reload every time it detects (or merely
+	// suspects, for sources with no cheap change signal) that the
+	// source may have changed, until ctx is done.
+	Watch(ctx context.Context, reload func())
+}
+
+// openLoader resolves rawURL's scheme to a Loader: "file://" (also a
+// bare path) loads from the local filesystem, "http://" and "https://"
+// poll a remote URL using ETag to avoid needless reparsing.
+func openLoader(rawURL string) (Loader, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme == "" {
+		return NewFileLoader(rawURL), nil
+	}
+
+	switch u.Scheme {
+	case "file":
+		return NewFileLoader(u.Path), nil
+	case "http", "https":
+		return NewHTTPLoader(rawURL), nil
+	default:
+		return nil, fmt.Errorf("hosts: unsupported source scheme %q", u.Scheme)
+	}
+}
+
+// FileLoader loads hosts entries from the local filesystem and watches
+// for changes via fsnotify, falling back to mtime polling if a watch
+// can't be established (e.g. inotify limits, or a filesystem that
+// doesn't support it).
+type FileLoader struct {
+	filename string
+}
+
+func NewFileLoader(filename string) *FileLoader {
+	return &FileLoader{filename: filename}
+}
+
+func (l *FileLoader) Load() (io.ReadCloser, error) {
+	return os.Open(l.filename)
+}
+
+func (l *FileLoader) Watch(ctx context.Context, reload func()) {
+	// Watch the parent directory, not l.filename itself: deployments
+	// ship an updated hosts file by writing a temp file and renaming it
+	// over the target (so readers never see a half-written file), and
+	// on most platforms that rename does not generate a Write/Create
+	// event against the original watched path/inode, so watching the
+	// file directly goes silent after the first such update.
+	dir := filepath.Dir(l.filename)
+	name := filepath.Base(l.filename)
+
+	if w, err := fsnotify.NewWatcher(); err == nil {
+		defer w.Close()
+		if err := w.Add(dir); err == nil {
+			l.watchEvents(ctx, w, name, reload)
+			return
+		}
+	}
+	l.poll(ctx, reload)
+}
+
+func (l *FileLoader) watchEvents(ctx context.Context, w *fsnotify.Watcher, name string, reload func()) {
+	const changed = fsnotify.Write | fsnotify.Create | fsnotify.Rename
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-w.Events:
+			if !ok {
+				return
+			}
+			if ev.Op&changed != 0 && filepath.Base(ev.Name) == name {
+				reload()
+			}
+		case <-w.Errors:
+			// keep watching; a transient fsnotify error shouldn't stop
+			// us from picking up later changes.
+		}
+	}
+}
+
+func (l *FileLoader) poll(ctx context.Context, reload func()) {
+	var lastMod time.Time
+	ticker := time.NewTicker(DefaultPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fi, err := os.Stat(l.filename)
+			if err != nil {
+				continue
+			}
+			if mt := fi.ModTime(); mt.After(lastMod) {
+				lastMod = mt
+				reload()
+			}
+		}
+	}
+}
+
+// HTTPLoader loads hosts entries from a remote URL, using the ETag
+// response header (when present) to avoid refetching and reparsing an
+// unchanged source on every poll.
+type HTTPLoader struct {
+	url    string
+	client *http.Client
+	etag   string
+}
+
+func NewHTTPLoader(rawURL string) *HTTPLoader {
+	return &HTTPLoader{url: rawURL, client: http.DefaultClient}
+}
+
+func (l *HTTPLoader) Load() (io.ReadCloser, error) {
+	req, err := http.NewRequest(http.MethodGet, l.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if l.etag != "" {
+		req.Header.Set("If-None-Match", l.etag)
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		return nil, errNotModified
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("hosts: fetch %s: unexpected status %s", l.url, resp.Status)
+	}
+
+	l.etag = resp.Header.Get("ETag")
+	return resp.Body, nil
+}
+
+func (l *HTTPLoader) Watch(ctx context.Context, reload func()) {
+	ticker := time.NewTicker(DefaultPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reload()
+		}
+	}
+}
@@ -6,10 +6,28 @@ import (
 	"github.com/go-gost/core/hosts"
 )
 
+// AddrLookuper is implemented by hosts.HostMapper values that can also
+// resolve an IP back to the name(s) it was configured under, e.g. so
+// the forward handler and sniffer can annotate logs with the canonical
+// name of a sniffed destination IP.
+type AddrLookuper interface {
+	LookupAddr(ip net.IP) ([]string, bool)
+}
+
 type hostsRegistry struct {
 	registry[hosts.HostMapper]
 }
 
+var hostsReg hostsRegistry
+
+// HostsRegistry returns the global registry of named hosts.HostMapper
+// sources. Deployments can register a dynamically reloaded, URL-backed
+// mapper (see internal/util/hosts.Open) here by name so hops can
+// reference a central hosts list.
+func HostsRegistry() *hostsRegistry {
+	return &hostsReg
+}
+
 func (r *hostsRegistry) Register(name string, v hosts.HostMapper) error {
 	return r.registry.Register(name, v)
 }
@@ -37,3 +55,16 @@ func (w *hostsWrapper) Lookup(network, host string) ([]net.IP, bool) {
 	}
 	return v.Lookup(network, host)
 }
+
+// LookupAddr resolves ip back to its configured name(s), if the
+// underlying HostMapper supports it (see AddrLookuper).
+func (w *hostsWrapper) LookupAddr(ip net.IP) ([]string, bool) {
+	v := w.r.get(w.name)
+	if v == nil {
+		return nil, false
+	}
+	if al, ok := v.(AddrLookuper); ok {
+		return al.LookupAddr(ip)
+	}
+	return nil, false
+}
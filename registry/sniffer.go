@@ -0,0 +1,26 @@
+package registry
+
+import (
+	"github.com/go-gost/x/internal/util/forward"
+)
+
+type snifferRegistry struct {
+	registry[forward.Sniffer]
+}
+
+func (r *snifferRegistry) Register(name string, v forward.Sniffer) error {
+	return r.registry.Register(name, v)
+}
+
+func (r *snifferRegistry) Get(name string) forward.Sniffer {
+	return r.registry.Get(name)
+}
+
+var sniffers snifferRegistry
+
+// SnifferRegistry returns the global registry of forward.Sniffer
+// detectors, allowing third parties to Register additional protocol
+// detectors alongside the built-in ones from forward.Sniffers().
+func SnifferRegistry() *snifferRegistry {
+	return &sniffers
+}